@@ -6,13 +6,14 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"sort"
 	"strings"
 
-	"github.com/go-clang/clang-v3.9/clang"
 	"github.com/kr/pretty"
 	"github.com/mewkiz/pkg/jsonutil"
 	"github.com/mewkiz/pkg/term"
 	"github.com/mewspring/cc"
+	"github.com/mewspring/cdoc2json/docs"
 	"github.com/pkg/errors"
 )
 
@@ -52,11 +53,11 @@ func main() {
 	srcPaths := flag.Args()
 
 	// Parse doc comments JSON file.
-	docComments, err := parseDocComments(jsonPath)
+	out, err := parseDocComments(jsonPath)
 	if err != nil {
 		log.Fatalf("%+v", err)
 	}
-	pretty.Println("docComments:", docComments)
+	pretty.Println("docComments:", out)
 
 	// Parse source files.
 	for _, srcPath := range srcPaths {
@@ -65,7 +66,7 @@ func main() {
 			warn.Printf("%+v", err)
 			// continue with partial AST.
 		}
-		if src, change := addComments(srcFile, docComments); change {
+		if src, change := addComments(srcFile, out); change {
 			dbg.Printf("adding comments to %q", srcPath)
 			if err := ioutil.WriteFile(srcPath, []byte(src), 0644); err != nil {
 				log.Fatalf("%+v", errors.WithStack(err))
@@ -74,25 +75,63 @@ func main() {
 	}
 }
 
-// addComments and doc comments to the given source file, connecting identifiers
-// in the source code with the associated doc comments, as recorded in
-// docComments, which maps from identifier to doc comment.
-func addComments(srcFile *SourceFile, docComments map[string]string) ([]byte, bool) {
+// addComments adds doc comments and notes to the given source file,
+// connecting identifiers in the source code with the associated doc
+// comments, and restoring BUG/TODO/FIXME notes at the source line they were
+// originally extracted from, as recorded in out.
+func addComments(srcFile *SourceFile, out docs.Output) ([]byte, bool) {
 	oldSrc := string(srcFile.Buf)
 	lines := strings.Split(oldSrc, "\n")
-	// comments maps from line number to comment.
-	comments := make(map[uint32]string)
-	decls := findGlobalDecls(srcFile.File)
+	decls := docs.WalkDecls(srcFile.File.Root)
+	sort.Slice(decls, func(i, j int) bool {
+		return docs.Less(decls[i].Node.Loc, decls[j].Node.Loc)
+	})
+	nodes := make([]*cc.Node, len(decls))
+	for i, decl := range decls {
+		nodes[i] = decl.Node
+	}
+	// existing records the comments already present in srcFile, associated
+	// with decls through the same lead/line/free rules used by cdoc2json.
+	// It is used to avoid re-inserting a comment for a decl that is already
+	// documented. Restored doc comments are placed above each decl's current
+	// line, found by qualified name, so they land at the correct declaration
+	// even after edits have shifted line numbers.
+	existingComments, warnings := docs.ScanComments(srcFile.Path, srcFile.Buf)
+	for _, w := range warnings {
+		warn.Print(w)
+	}
+	existing := docs.NewCommentMap(nodes, existingComments, lines)
+	// comments maps from line number to the comments to insert above it.
+	comments := make(map[uint32][]string)
 	for _, decl := range decls {
-		if comment, ok := docComments[decl.Body.Spelling()]; ok {
-			comment = normalizeComment(comment)
-			comments[decl.Loc.Line] = comment
+		comment, ok := out.Docs[decl.Name]
+		if !ok {
+			continue
+		}
+		if existing.HasLead(decl.Node) {
+			// decl is already documented; leave it alone.
+			continue
+		}
+		comments[decl.Node.Loc.Line] = append(comments[decl.Node.Loc.Line], normalizeComment(comment))
+	}
+	for kind, entries := range out.Notes {
+		for _, entry := range entries {
+			if entry.File != srcFile.Path {
+				// note belongs to a different source file; don't drop it,
+				// just skip restoring it here.
+				continue
+			}
+			comments[entry.Line] = append(comments[entry.Line], formatNote(kind, entry))
 		}
 	}
+	if fileDoc, ok := out.FileDoc[srcFile.Path]; ok && !hasFileDoc(existingComments, lines, nodes) {
+		// Prepend ahead of whatever else is slated for line 1.
+		comments[1] = append([]string{fileDoc.Lit}, comments[1]...)
+	}
 	newSrc := &strings.Builder{}
 	for i, line := range lines {
 		lineNr := uint32(i + 1)
-		if comment, ok := comments[lineNr]; ok {
+		for _, comment := range comments[lineNr] {
 			fmt.Fprintf(newSrc, "%s\n", comment)
 		}
 		fmt.Fprintf(newSrc, "%s\n", line)
@@ -101,6 +140,38 @@ func addComments(srcFile *SourceFile, docComments map[string]string) ([]byte, bo
 	return []byte(newSrc.String()), change
 }
 
+// hasFileDoc reports whether srcFile already has a file-level doc comment,
+// so addComments doesn't insert a second one above it.
+func hasFileDoc(existingComments []docs.Comment, lines []string, nodes []*cc.Node) bool {
+	var firstDeclLoc *cc.Location
+	if len(nodes) > 0 {
+		loc := nodes[0].Loc
+		firstDeclLoc = &loc
+	}
+	// Merge line comments first, as cdoc2json does, so a file doc written as
+	// a run of "//" lines is recognized as one block.
+	fileDoc, _ := docs.ExtractFileDoc(docs.MergeLineComments(existingComments), lines, firstDeclLoc)
+	return fileDoc != nil
+}
+
+// formatNote renders a note as one or more "// " line comments, prefixing
+// every line of entry.Body (which may span multiple lines, if extracted
+// from a multi-line block comment) so the restored note stays commented
+// out.
+func formatNote(kind string, entry docs.NoteEntry) string {
+	header := fmt.Sprintf("%s(%s): ", kind, entry.Author)
+	bodyLines := strings.Split(entry.Body, "\n")
+	lines := make([]string, len(bodyLines))
+	for i, bodyLine := range bodyLines {
+		if i == 0 {
+			lines[i] = "// " + header + bodyLine
+			continue
+		}
+		lines[i] = "// " + bodyLine
+	}
+	return strings.Join(lines, "\n")
+}
+
 func normalizeComment(comment string) string {
 	lines := strings.Split(comment, "\n")
 	for i, line := range lines {
@@ -117,24 +188,6 @@ func insert(ss []string, pos int, s string) []string {
 	return append(new, ss[pos:]...)
 }
 
-func findGlobalDecls(file *cc.File) []*cc.Node {
-	var decls []*cc.Node
-	root := file.Root
-	for _, child := range root.Children {
-		if child.Body.Kind() == clang.Cursor_Namespace {
-			root = child
-		}
-	}
-	// TODO: handle namespaces.
-	for _, child := range root.Children {
-		switch child.Body.Kind() {
-		case clang.Cursor_VarDecl, clang.Cursor_FunctionDecl:
-			decls = append(decls, child)
-		}
-	}
-	return decls
-}
-
 type SourceFile struct {
 	Path string
 	Buf  []byte
@@ -158,11 +211,10 @@ func parseSourceFile(srcPath string, clangArgs []string) (*SourceFile, error) {
 	return srcFile, nil
 }
 
-func parseDocComments(jsonPath string) (map[string]string, error) {
-	// docComments maps from identifier to doc comment.
-	docComments := make(map[string]string)
-	if err := jsonutil.ParseFile(jsonPath, &docComments); err != nil {
-		return nil, errors.WithStack(err)
+func parseDocComments(jsonPath string) (docs.Output, error) {
+	var out docs.Output
+	if err := jsonutil.ParseFile(jsonPath, &out); err != nil {
+		return docs.Output{}, errors.WithStack(err)
 	}
-	return docComments, nil
+	return out, nil
 }
@@ -0,0 +1,100 @@
+// cdoc2html -clang_args="-m32|-I./include" -json_path=doc_comments.json -output=site foo.h
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mewkiz/pkg/jsonutil"
+	"github.com/mewkiz/pkg/term"
+	"github.com/mewspring/cc"
+	"github.com/mewspring/cdoc2json/docs"
+	"github.com/mewspring/cdoc2json/docs/render"
+	"github.com/pkg/errors"
+)
+
+var (
+	// dbg is a logger with the "cdoc2html:" prefix which logs debug messages
+	// to standard error.
+	dbg = log.New(os.Stderr, term.CyanBold("cdoc2html:")+" ", 0)
+	// warn is a logger with the "cdoc2html:" prefix which logs warning
+	// messages to standard error.
+	warn = log.New(os.Stderr, term.RedBold("cdoc2html:")+" ", 0)
+)
+
+func main() {
+	// Parse command line arguments.
+	var (
+		// doc comments JSON path.
+		jsonPath string
+		// Output directory for rendered HTML and Markdown.
+		outDir string
+		// Clang arguments.
+		clangArgs []string
+	)
+	var clangArgsRaw string
+	flag.StringVar(&jsonPath, "json_path", "doc_comments.json", "doc comments JSON path")
+	flag.StringVar(&outDir, "output", "site", "output directory for rendered HTML and Markdown")
+	flag.StringVar(&clangArgsRaw, "clang_args", "", "pipe-separated Clang arguments")
+	flag.Parse()
+	clangArgs = strings.Split(clangArgsRaw, "|")
+	srcPaths := flag.Args()
+
+	out, err := parseDocComments(jsonPath)
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+
+	var decls []docs.QualifiedDecl
+	for _, srcPath := range srcPaths {
+		dbg.Printf("parsing %q", srcPath)
+		file, err := cc.ParseFile(srcPath, clangArgs...)
+		if err != nil {
+			warn.Printf("%+v", errors.WithStack(err))
+			continue
+		}
+		decls = append(decls, docs.WalkDecls(file.Root)...)
+		file.Close()
+	}
+
+	site := render.Build(decls, out.Docs)
+	site.Notes = out.Notes
+
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		log.Fatalf("%+v", errors.WithStack(err))
+	}
+	if err := renderTo(filepath.Join(outDir, "index.html"), func(buf *bytes.Buffer) error {
+		return render.RenderHTML(buf, site, nil)
+	}); err != nil {
+		log.Fatalf("%+v", err)
+	}
+	if err := renderTo(filepath.Join(outDir, "index.md"), func(buf *bytes.Buffer) error {
+		return render.RenderMarkdown(buf, site)
+	}); err != nil {
+		log.Fatalf("%+v", err)
+	}
+}
+
+// renderTo renders into a buffer using render and writes the result to
+// path.
+func renderTo(path string, render func(buf *bytes.Buffer) error) error {
+	buf := &bytes.Buffer{}
+	if err := render(buf); err != nil {
+		return errors.WithStack(err)
+	}
+	dbg.Printf("creating %q", path)
+	return errors.WithStack(ioutil.WriteFile(path, buf.Bytes(), 0644))
+}
+
+func parseDocComments(jsonPath string) (docs.Output, error) {
+	var out docs.Output
+	if err := jsonutil.ParseFile(jsonPath, &out); err != nil {
+		return docs.Output{}, errors.WithStack(err)
+	}
+	return out, nil
+}
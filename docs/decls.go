@@ -0,0 +1,95 @@
+package docs
+
+import (
+	"github.com/go-clang/clang-v3.9/clang"
+	"github.com/mewspring/cc"
+)
+
+// QualifiedDecl pairs a declaration node with the qualified name it is
+// recorded under in the doc comments JSON, e.g. "struct.S", "enum.E.A",
+// "macro.FOO", or "S::field". Qualifying by kind and enclosing scope keeps
+// declarations that share a bare spelling (a struct and a typedef named
+// "Foo", two enums each with a "None" enumerator, ...) from colliding on a
+// single identifier key.
+type QualifiedDecl struct {
+	Node *cc.Node
+	Name string
+}
+
+// WalkDecls recursively visits root's children looking for declarations
+// that can carry a doc comment: variables, functions, structs, unions,
+// enums (and their enumerators), typedefs, macros, and struct/union
+// fields. Namespace children are visited recursively, prefixing names with
+// the dotted namespace path.
+func WalkDecls(root *cc.Node) []QualifiedDecl {
+	var decls []QualifiedDecl
+	var walk func(n *cc.Node, prefix string)
+	walk = func(n *cc.Node, prefix string) {
+		for _, child := range n.Children {
+			switch child.Body.Kind() {
+			case clang.Cursor_Namespace:
+				walk(child, qualify(prefix, child.Body.Spelling()))
+			case clang.Cursor_VarDecl, clang.Cursor_FunctionDecl:
+				decls = append(decls, QualifiedDecl{Node: child, Name: qualify(prefix, child.Body.Spelling())})
+			case clang.Cursor_TypedefDecl:
+				decls = append(decls, QualifiedDecl{Node: child, Name: qualify(prefix, "typedef."+child.Body.Spelling())})
+			case clang.Cursor_MacroDefinition:
+				decls = append(decls, QualifiedDecl{Node: child, Name: qualify(prefix, "macro."+child.Body.Spelling())})
+			case clang.Cursor_StructDecl, clang.Cursor_UnionDecl:
+				decls = append(decls, recordDecls(child, prefix)...)
+			case clang.Cursor_EnumDecl:
+				decls = append(decls, enumDecls(child, prefix)...)
+			}
+		}
+	}
+	walk(root, "")
+	return decls
+}
+
+// recordDecls returns the QualifiedDecl for a struct or union declaration,
+// followed by one for each of its fields, named "Record::field".
+func recordDecls(n *cc.Node, prefix string) []QualifiedDecl {
+	kind := "struct"
+	if n.Body.Kind() == clang.Cursor_UnionDecl {
+		kind = "union"
+	}
+	decls := []QualifiedDecl{
+		{Node: n, Name: qualify(prefix, kind+"."+n.Body.Spelling())},
+	}
+	for _, field := range n.Children {
+		if field.Body.Kind() == clang.Cursor_FieldDecl {
+			decls = append(decls, QualifiedDecl{
+				Node: field,
+				Name: qualify(prefix, n.Body.Spelling()+"::"+field.Body.Spelling()),
+			})
+		}
+	}
+	return decls
+}
+
+// enumDecls returns the QualifiedDecl for an enum declaration, followed by
+// one for each of its enumerators, named "enum.Enum.Enumerator".
+func enumDecls(n *cc.Node, prefix string) []QualifiedDecl {
+	name := qualify(prefix, "enum."+n.Body.Spelling())
+	decls := []QualifiedDecl{
+		{Node: n, Name: name},
+	}
+	for _, enumerator := range n.Children {
+		if enumerator.Body.Kind() == clang.Cursor_EnumConstantDecl {
+			decls = append(decls, QualifiedDecl{
+				Node: enumerator,
+				Name: name + "." + enumerator.Body.Spelling(),
+			})
+		}
+	}
+	return decls
+}
+
+// qualify joins a dotted namespace prefix and a name, omitting the
+// separator when prefix is empty.
+func qualify(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
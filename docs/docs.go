@@ -2,15 +2,228 @@
 package docs
 
 import (
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"sort"
+	"strings"
+
 	"github.com/mewspring/cc"
 )
 
+// Comment represents a single comment (line or block) extracted from a
+// C/C++ source file.
 type Comment struct {
 	Lit string
 	Loc cc.Location
+	// Assoc records how the comment relates to the declaration it is
+	// associated with in a CommentMap. Zero value (AssocFree) until placed
+	// into a CommentMap by NewCommentMap.
+	Assoc Assoc
+}
+
+// EndLine returns the line number of the last line of the comment.
+func (c Comment) EndLine() uint32 {
+	return c.Loc.Line + uint32(strings.Count(c.Lit, "\n"))
 }
 
+// DocComment associates a declaration with its doc comment. Name is the
+// declaration's qualified name, as produced by WalkDecls, under which the
+// comment is recorded in the doc comments JSON.
 type DocComment struct {
 	Decl    *cc.Node
+	Name    string
 	Comment Comment
 }
+
+// Less reports whether location a precedes location b.
+func Less(a, b cc.Location) bool {
+	switch {
+	case a.Line < b.Line:
+		return true
+	case a.Line > b.Line:
+		return false
+	}
+	// case a.Line == b.Line:
+	return a.Col < b.Col
+}
+
+// ScanComments scans src (the contents of the file at filename) and returns
+// the sorted list of comments it contains, along with any non-fatal
+// scanner warnings (the '#' starting a preprocessor directive is tolerated
+// and produces no warning; the rest of a directive line is scanned like
+// any other source text).
+func ScanComments(filename string, src []byte) (comments []Comment, warnings []string) {
+	fset := token.NewFileSet()
+	file := fset.AddFile(filename, 1, len(src))
+	eh := func(pos token.Position, msg string) {
+		if msg == "illegal character U+0023 '#'" {
+			// Ignore pre-process directives.
+			return
+		}
+		warnings = append(warnings, fmt.Sprintf("pos: %v, msg: %v", pos, msg))
+	}
+	s := &scanner.Scanner{}
+	s.Init(file, src, eh, scanner.ScanComments)
+	for {
+		p, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok != token.COMMENT {
+			continue
+		}
+		pos := fset.Position(p)
+		loc := cc.Location{
+			File: pos.Filename,
+			Line: uint32(pos.Line),
+			Col:  uint32(pos.Column),
+		}
+		comments = append(comments, Comment{Lit: lit, Loc: loc})
+	}
+	sort.Slice(comments, func(i, j int) bool {
+		return Less(comments[i].Loc, comments[j].Loc)
+	})
+	return comments, warnings
+}
+
+// MergeLineComments merges consecutive "//" line comments (with no
+// intervening blank lines) into a single multi-line Comment, mirroring how
+// Go's scanner groups consecutive line comments into one comment group.
+func MergeLineComments(comments []Comment) []Comment {
+	var merged []Comment
+	for i := 0; i < len(comments); i++ {
+		c := comments[i]
+		for i+1 < len(comments) && isConsecutiveLineComments(c, comments[i+1]) {
+			i++
+			c.Lit += "\n" + comments[i].Lit
+		}
+		merged = append(merged, c)
+	}
+	return merged
+}
+
+func isConsecutiveLineComments(a, b Comment) bool {
+	if !strings.HasPrefix(a.Lit, "//") || !strings.HasPrefix(b.Lit, "//") {
+		return false
+	}
+	return a.EndLine() == b.Loc.Line-1
+}
+
+// Assoc specifies how a comment is associated with the declaration it is
+// recorded against in a CommentMap.
+type Assoc int
+
+const (
+	// AssocFree indicates a comment that does not directly document a
+	// declaration, but is associated with the nearest preceding declaration
+	// as its enclosing scope.
+	AssocFree Assoc = iota
+	// AssocLead indicates a comment that immediately precedes, and
+	// documents, the declaration that follows it.
+	AssocLead
+	// AssocLine indicates a comment that trails a declaration on the same
+	// physical line.
+	AssocLine
+)
+
+// CommentMap records the association between declarations and the comments
+// that document them, following lead/line/free comment rules analogous to
+// go/ast.NewCommentMap.
+type CommentMap map[*cc.Node][]Comment
+
+// NewCommentMap associates each comment in comments with the declaration in
+// decls that it documents:
+//
+//   - a comment that starts on the same physical line as a preceding
+//     declaration is a line comment for that declaration.
+//   - a comment immediately preceding a declaration, separated from it by
+//     nothing but blank or preprocessor lines, is a lead comment for that
+//     declaration.
+//   - any other comment is a free comment, associated with the nearest
+//     preceding declaration as its enclosing scope. A free comment with no
+//     preceding declaration is not recorded in the map.
+//
+// decls and comments must be sorted by source location. lines holds the
+// source file split into physical lines (1-indexed through Loc.Line), and
+// is used to tell blank and preprocessor lines apart from code when
+// deciding lead comment adjacency.
+func NewCommentMap(decls []*cc.Node, comments []Comment, lines []string) CommentMap {
+	cmap := make(CommentMap)
+	i := 0 // index of the next not-yet-passed declaration.
+	var prev *cc.Node
+	for _, comment := range comments {
+		for i < len(decls) && !Less(comment.Loc, decls[i].Loc) {
+			prev = decls[i]
+			i++
+		}
+		var next *cc.Node
+		if i < len(decls) {
+			next = decls[i]
+		}
+		switch {
+		case prev != nil && prev.Loc.Line == comment.Loc.Line:
+			cmap.add(prev, comment, AssocLine)
+		case next != nil && onlyWhitespaceBetween(lines, comment.EndLine(), next.Loc.Line):
+			cmap.add(next, comment, AssocLead)
+		case prev != nil:
+			cmap.add(prev, comment, AssocFree)
+		}
+	}
+	return cmap
+}
+
+func (cmap CommentMap) add(decl *cc.Node, comment Comment, assoc Assoc) {
+	comment.Assoc = assoc
+	cmap[decl] = append(cmap[decl], comment)
+}
+
+// onlyWhitespaceBetween reports whether every line strictly between
+// commEndLine and declLine (both 1-indexed) is blank or a preprocessor
+// directive.
+func onlyWhitespaceBetween(lines []string, commEndLine, declLine uint32) bool {
+	for l := commEndLine + 1; l < declLine; l++ {
+		if l < 1 || int(l) > len(lines) || !isWhitespaceLine(lines[l-1]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isWhitespaceLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return trimmed == "" || strings.HasPrefix(trimmed, "#")
+}
+
+// HasLead reports whether decl already has a lead (doc) comment in cmap.
+// Line and free comments don't count, since neither documents decl.
+func (cmap CommentMap) HasLead(decl *cc.Node) bool {
+	for _, comment := range cmap[decl] {
+		if comment.Assoc == AssocLead {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns a new CommentMap restricted to the comments associated
+// with decl.
+func (cmap CommentMap) Filter(decl *cc.Node) CommentMap {
+	if cs, ok := cmap[decl]; ok {
+		return CommentMap{decl: cs}
+	}
+	return CommentMap{}
+}
+
+// Comments returns the list of comments recorded in cmap, sorted by source
+// location.
+func (cmap CommentMap) Comments() []Comment {
+	var comments []Comment
+	for _, cs := range cmap {
+		comments = append(comments, cs...)
+	}
+	sort.Slice(comments, func(i, j int) bool {
+		return Less(comments[i].Loc, comments[j].Loc)
+	})
+	return comments
+}
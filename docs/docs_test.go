@@ -0,0 +1,122 @@
+package docs
+
+import (
+	"testing"
+
+	"github.com/mewspring/cc"
+)
+
+func TestNewCommentMap(t *testing.T) {
+	decl := func(line uint32) *cc.Node {
+		return &cc.Node{Loc: cc.Location{File: "foo.h", Line: line}}
+	}
+	comment := func(line uint32, lit string) Comment {
+		return Comment{Lit: lit, Loc: cc.Location{File: "foo.h", Line: line}}
+	}
+	golden := []struct {
+		name    string
+		decl    *cc.Node
+		comment Comment
+		lines   []string
+		// next, if non-nil, is a second decl following decl.
+		next *cc.Node
+		want Assoc
+	}{
+		{
+			name:    "lead comment immediately above decl",
+			decl:    decl(2),
+			comment: comment(1, "// Foo does a thing."),
+			lines:   []string{"// Foo does a thing.", "void Foo(void);"},
+			want:    AssocLead,
+		},
+		{
+			name:    "lead comment separated from decl by blank lines only",
+			decl:    decl(4),
+			comment: comment(1, "// Foo does a thing."),
+			lines:   []string{"// Foo does a thing.", "", "", "void Foo(void);"},
+			want:    AssocLead,
+		},
+		{
+			name:    "lead comment separated from decl by a preprocessor line",
+			decl:    decl(3),
+			comment: comment(1, "// Foo does a thing."),
+			lines:   []string{"// Foo does a thing.", "#ifdef DEBUG", "void Foo(void);"},
+			want:    AssocLead,
+		},
+		{
+			name:    "line comment trailing a decl on the same line",
+			decl:    decl(1),
+			comment: comment(1, "// bytes remaining."),
+			lines:   []string{"int n; // bytes remaining."},
+			want:    AssocLine,
+		},
+		{
+			name:    "trailing comment after the last decl is free, not lead",
+			decl:    decl(1),
+			comment: comment(3, "// end of file."),
+			lines:   []string{"void Foo(void);", "", "// end of file."},
+			want:    AssocFree,
+		},
+		{
+			name:    "comment separated from the next decl by code is free",
+			decl:    decl(1),
+			comment: comment(2, "// stray note."),
+			lines:   []string{"void Foo(void);", "// stray note.", "int x = 1;", "void Bar(void);"},
+			next:    decl(4),
+			want:    AssocFree,
+		},
+	}
+	for _, g := range golden {
+		t.Run(g.name, func(t *testing.T) {
+			decls := []*cc.Node{g.decl}
+			if g.next != nil {
+				decls = append(decls, g.next)
+			}
+			cmap := NewCommentMap(decls, []Comment{g.comment}, g.lines)
+			cs := cmap[g.decl]
+			if len(cs) != 1 {
+				t.Fatalf("expected 1 comment associated with decl, got %d", len(cs))
+			}
+			if got := cs[0].Assoc; got != g.want {
+				t.Errorf("Assoc = %v, want %v", got, g.want)
+			}
+		})
+	}
+}
+
+// TestNewCommentMapLeadOnlyForDocComment guards against the regression where
+// a Free or Line comment clobbered a decl's real lead comment: of a lead,
+// a line and a free comment, NewCommentMap marks only the lead comment as
+// AssocLead, which is the only association cdoc2json's addDocComments keeps.
+func TestNewCommentMapLeadOnlyForDocComment(t *testing.T) {
+	fooDecl := &cc.Node{Loc: cc.Location{File: "foo.h", Line: 2}}
+	barDecl := &cc.Node{Loc: cc.Location{File: "foo.h", Line: 4}}
+	lines := []string{
+		"// Foo does a thing.",
+		"void Foo(void); // trailing note, not a doc comment.",
+		"",
+		"void Bar(void);",
+		"// stray comment after the last decl.",
+	}
+	comments := []Comment{
+		{Lit: "// Foo does a thing.", Loc: cc.Location{File: "foo.h", Line: 1}},
+		{Lit: "// trailing note, not a doc comment.", Loc: cc.Location{File: "foo.h", Line: 2}},
+		{Lit: "// stray comment after the last decl.", Loc: cc.Location{File: "foo.h", Line: 5}},
+	}
+	decls := []*cc.Node{fooDecl, barDecl}
+	cmap := NewCommentMap(decls, comments, lines)
+	var leads int
+	for _, decl := range decls {
+		for _, c := range cmap[decl] {
+			if c.Assoc == AssocLead {
+				leads++
+			}
+		}
+	}
+	if leads != 1 {
+		t.Fatalf("expected exactly 1 lead comment across all decls, got %d", leads)
+	}
+	if cs := cmap[fooDecl]; len(cs) == 0 || cs[0].Assoc != AssocLead || cs[0].Lit != "// Foo does a thing." {
+		t.Errorf("Foo's lead comment: got %+v", cs)
+	}
+}
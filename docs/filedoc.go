@@ -0,0 +1,122 @@
+package docs
+
+import (
+	"strings"
+
+	"github.com/mewspring/cc"
+)
+
+// FileDoc is a file-level doc comment: the comment block at the top of a
+// header that precedes any declaration, analogous to ast.File.Doc in
+// go/ast.
+type FileDoc struct {
+	// Lit is the raw comment literal, including delimiters.
+	Lit string `json:"lit"`
+	// File is the argument of a \file directive inside the comment, if any.
+	File string `json:"file,omitempty"`
+	// Mainpage is the argument of a \mainpage directive inside the comment,
+	// if any.
+	Mainpage string `json:"mainpage,omitempty"`
+	// Groups maps \defgroup names to their descriptions, letting a renderer
+	// build a group/module index page.
+	Groups map[string]string `json:"groups,omitempty"`
+}
+
+// ExtractFileDoc extracts the file-level doc comment from comments: the
+// first comment block that precedes firstDecl (or, if firstDecl is nil,
+// the whole file) and is separated from whatever follows it by at least
+// one blank line. It returns the remaining comments unchanged if no file
+// doc is found there.
+func ExtractFileDoc(comments []Comment, lines []string, firstDecl *cc.Location) (fileDoc *FileDoc, rest []Comment) {
+	if len(comments) == 0 {
+		return nil, comments
+	}
+	first := comments[0]
+	if firstDecl != nil && !Less(first.Loc, *firstDecl) {
+		return nil, comments
+	}
+	boundaryLine := uint32(len(lines)) + 1
+	if firstDecl != nil {
+		boundaryLine = firstDecl.Line
+	}
+	if len(comments) > 1 && comments[1].Loc.Line < boundaryLine {
+		boundaryLine = comments[1].Loc.Line
+	}
+	if !hasBlankLineBetween(lines, first.EndLine(), boundaryLine) {
+		return nil, comments
+	}
+	return parseFileDoc(first), comments[1:]
+}
+
+// hasBlankLineBetween reports whether at least one of the lines strictly
+// between fromLine and toLine (both 1-indexed) is blank.
+func hasBlankLineBetween(lines []string, fromLine, toLine uint32) bool {
+	for l := fromLine + 1; l < toLine; l++ {
+		if l >= 1 && int(l) <= len(lines) && strings.TrimSpace(lines[l-1]) == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// fileDocDirectives are the Doxygen directives parseFileDoc recognizes
+// inside a file-level doc comment.
+var fileDocDirectives = map[string]bool{
+	"file": true, "mainpage": true, "defgroup": true,
+}
+
+// parseFileDoc records comment as a FileDoc, routing \file, \mainpage, and
+// \defgroup directives found inside it into their dedicated fields.
+func parseFileDoc(comment Comment) *FileDoc {
+	fd := &FileDoc{Lit: comment.Lit}
+	text := StripCommentDelims(comment.Lit)
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		directive, rest := splitDirective(line)
+		switch directive {
+		case "file":
+			fd.File = rest
+		case "mainpage":
+			fd.Mainpage = rest
+		case "defgroup":
+			name, desc := splitFirstWord(rest)
+			if fd.Groups == nil {
+				fd.Groups = make(map[string]string)
+			}
+			fd.Groups[name] = desc
+		}
+	}
+	return fd
+}
+
+// splitDirective splits a trimmed comment line into a recognized Doxygen
+// directive ("file", "mainpage", "defgroup") and the rest of the line, or
+// returns an empty directive if line does not start with one.
+func splitDirective(line string) (directive, rest string) {
+	for _, prefix := range [...]string{"@", "\\"} {
+		if !strings.HasPrefix(line, prefix) {
+			continue
+		}
+		fields := strings.SplitN(line[len(prefix):], " ", 2)
+		if !fileDocDirectives[fields[0]] {
+			continue
+		}
+		directive = fields[0]
+		if len(fields) > 1 {
+			rest = strings.TrimSpace(fields[1])
+		}
+		return directive, rest
+	}
+	return "", ""
+}
+
+// splitFirstWord splits s into its first whitespace-delimited word and the
+// remainder of the string.
+func splitFirstWord(s string) (word, rest string) {
+	fields := strings.SplitN(s, " ", 2)
+	word = fields[0]
+	if len(fields) > 1 {
+		rest = strings.TrimSpace(fields[1])
+	}
+	return word, rest
+}
@@ -0,0 +1,66 @@
+package docs
+
+import (
+	"testing"
+
+	"github.com/mewspring/cc"
+)
+
+func TestExtractFileDoc(t *testing.T) {
+	golden := []struct {
+		name      string
+		comments  []Comment
+		lines     []string
+		firstDecl *cc.Location
+		wantDoc   bool
+	}{
+		{
+			name:     "leading comment separated from the decl by a blank line is a file doc",
+			comments: []Comment{{Lit: "// Package foo does a thing.", Loc: cc.Location{File: "foo.h", Line: 1}}},
+			lines:    []string{"// Package foo does a thing.", "", "void Foo(void);"},
+			firstDecl: &cc.Location{
+				File: "foo.h", Line: 3,
+			},
+			wantDoc: true,
+		},
+		{
+			name:     "leading comment immediately above the decl is not a file doc",
+			comments: []Comment{{Lit: "// Foo does a thing.", Loc: cc.Location{File: "foo.h", Line: 1}}},
+			lines:    []string{"// Foo does a thing.", "void Foo(void);"},
+			firstDecl: &cc.Location{
+				File: "foo.h", Line: 2,
+			},
+			wantDoc: false,
+		},
+		{
+			name:      "no decls in the file and a blank line after the comment",
+			comments:  []Comment{{Lit: "// Package foo does a thing.", Loc: cc.Location{File: "foo.h", Line: 1}}},
+			lines:     []string{"// Package foo does a thing.", "", ""},
+			firstDecl: nil,
+			wantDoc:   true,
+		},
+		{
+			name:     "comment starts after the first decl",
+			comments: []Comment{{Lit: "// trailing.", Loc: cc.Location{File: "foo.h", Line: 3}}},
+			lines:    []string{"void Foo(void);", "", "// trailing."},
+			firstDecl: &cc.Location{
+				File: "foo.h", Line: 1,
+			},
+			wantDoc: false,
+		},
+	}
+	for _, g := range golden {
+		t.Run(g.name, func(t *testing.T) {
+			fileDoc, rest := ExtractFileDoc(g.comments, g.lines, g.firstDecl)
+			if (fileDoc != nil) != g.wantDoc {
+				t.Fatalf("ExtractFileDoc returned fileDoc=%v, want present=%v", fileDoc, g.wantDoc)
+			}
+			if g.wantDoc && len(rest) != len(g.comments)-1 {
+				t.Errorf("rest = %d comments, want %d", len(rest), len(g.comments)-1)
+			}
+			if !g.wantDoc && len(rest) != len(g.comments) {
+				t.Errorf("rest = %d comments, want unchanged %d", len(rest), len(g.comments))
+			}
+		})
+	}
+}
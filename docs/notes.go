@@ -0,0 +1,88 @@
+package docs
+
+import (
+	"regexp"
+	"strings"
+)
+
+// NoteEntry records a single BUG/TODO/FIXME marker extracted from a
+// comment, mirroring how Go's go/doc package collects "BUG(who): ..."
+// markers into a package's Bugs list.
+type NoteEntry struct {
+	// Author is the identifier between the marker's parentheses, e.g. "who"
+	// in "BUG(who): ...".
+	Author string `json:"author"`
+	// Body is the marker text with the "KIND(author): " prefix stripped.
+	Body string `json:"body"`
+	// File is the path of the source file the marker was found in.
+	File string `json:"file"`
+	// Line is the 1-indexed source line the marker's comment starts on.
+	Line uint32 `json:"line"`
+}
+
+// noteMarkerRE matches a "BUG(author): ", "TODO(author): ", or
+// "FIXME(author): " marker at the start of a (comment-delimiter-stripped)
+// comment.
+var noteMarkerRE = regexp.MustCompile(`^(BUG|TODO|FIXME)\(([^)]*)\):\s*`)
+
+// ExtractNotes splits comments into the BUG/TODO/FIXME notes they contain,
+// grouped by marker kind, and the remaining comments that carry no
+// recognized marker and should continue to flow into normal doc comment
+// association.
+func ExtractNotes(comments []Comment) (notes map[string][]NoteEntry, rest []Comment) {
+	notes = make(map[string][]NoteEntry)
+	for _, comment := range comments {
+		text := StripCommentDelims(comment.Lit)
+		m := noteMarkerRE.FindStringSubmatch(text)
+		if m == nil {
+			rest = append(rest, comment)
+			continue
+		}
+		kind, author := m[1], m[2]
+		entry := NoteEntry{
+			Author: author,
+			Body:   strings.TrimSpace(text[len(m[0]):]),
+			File:   comment.Loc.File,
+			Line:   comment.Loc.Line,
+		}
+		notes[kind] = append(notes[kind], entry)
+	}
+	return notes, rest
+}
+
+// StripCommentDelims strips "//", "/*", "*/", and per-line "*" continuation
+// markers from a (possibly multi-line) comment literal, leaving only the
+// comment's text.
+func StripCommentDelims(lit string) string {
+	lines := strings.Split(lit, "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "///"):
+			line = line[3:]
+		case strings.HasPrefix(line, "//"):
+			line = line[2:]
+		case strings.HasPrefix(line, "/**"):
+			line = line[3:]
+		case strings.HasPrefix(line, "/*"):
+			line = line[2:]
+		}
+		line = strings.TrimSuffix(strings.TrimSpace(line), "*/")
+		line = strings.TrimPrefix(strings.TrimSpace(line), "*")
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// Output is the top-level JSON schema produced by cdoc2json and consumed by
+// addcdocs: per-identifier doc comments alongside the BUG/TODO/FIXME notes
+// extracted separately from them.
+type Output struct {
+	// Docs maps from qualified identifier to its doc comment.
+	Docs map[string]string `json:"docs"`
+	// Notes maps from marker kind ("BUG", "TODO", "FIXME") to the notes of
+	// that kind found across all parsed source files.
+	Notes map[string][]NoteEntry `json:"notes"`
+	// FileDoc maps from header path to its file-level doc comment.
+	FileDoc map[string]FileDoc `json:"file_doc"`
+}
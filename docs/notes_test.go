@@ -0,0 +1,36 @@
+package docs
+
+import (
+	"testing"
+
+	"github.com/mewspring/cc"
+)
+
+func TestExtractNotes(t *testing.T) {
+	comments := []Comment{
+		{Lit: "// BUG(alice): off by one on the last element.", Loc: cc.Location{File: "foo.h", Line: 1}},
+		{Lit: "// Foo does a thing.", Loc: cc.Location{File: "foo.h", Line: 3}},
+		{Lit: "// TODO(bob): support IPv6.", Loc: cc.Location{File: "foo.h", Line: 5}},
+		{Lit: "/* FIXME(bob): this leaks on error.\n * See the cleanup path below.\n */", Loc: cc.Location{File: "foo.h", Line: 7}},
+	}
+	notes, rest := ExtractNotes(comments)
+
+	if len(rest) != 1 || rest[0].Lit != "// Foo does a thing." {
+		t.Fatalf("rest = %+v, want the one comment carrying no marker", rest)
+	}
+
+	wantBug := NoteEntry{Author: "alice", Body: "off by one on the last element.", File: "foo.h", Line: 1}
+	if bugs := notes["BUG"]; len(bugs) != 1 || bugs[0] != wantBug {
+		t.Errorf("notes[BUG] = %+v, want [%+v]", bugs, wantBug)
+	}
+
+	wantTodo := NoteEntry{Author: "bob", Body: "support IPv6.", File: "foo.h", Line: 5}
+	if todos := notes["TODO"]; len(todos) != 1 || todos[0] != wantTodo {
+		t.Errorf("notes[TODO] = %+v, want [%+v]", todos, wantTodo)
+	}
+
+	wantFixme := NoteEntry{Author: "bob", Body: "this leaks on error.\nSee the cleanup path below.", File: "foo.h", Line: 7}
+	if fixmes := notes["FIXME"]; len(fixmes) != 1 || fixmes[0] != wantFixme {
+		t.Errorf("notes[FIXME] = %+v, want [%+v]", fixmes, wantFixme)
+	}
+}
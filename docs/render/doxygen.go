@@ -0,0 +1,141 @@
+package render
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/mewspring/cdoc2json/docs"
+)
+
+// paramTag is a single "@param name description" entry.
+type paramTag struct {
+	Name string
+	Desc string
+}
+
+// FormatDoxygen renders a raw doc comment literal (with "//" or "/* */"
+// delimiters still attached) as semantic HTML, recognizing the @brief,
+// @param, @return/@returns, and \see Doxygen tags. Lines carrying no
+// recognized tag are rendered as a plain paragraph. targets maps a
+// cross-reference spelling (a symbol's qualified name, or its bare
+// spelling when unambiguous, as returned by linkifyTargets) to the
+// qualified anchor id to link an @see reference to; a @see reference not
+// found in targets is rendered as plain text.
+func FormatDoxygen(raw string, targets map[string]string) string {
+	text := docs.StripCommentDelims(raw)
+	var brief, body, returns, see []string
+	var params []paramTag
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		tag, rest := splitTag(line)
+		switch tag {
+		case "brief":
+			brief = append(brief, rest)
+		case "param":
+			name, desc := splitWord(rest)
+			params = append(params, paramTag{Name: name, Desc: desc})
+		case "return", "returns":
+			returns = append(returns, rest)
+		case "see":
+			see = append(see, rest)
+		default:
+			if line != "" {
+				body = append(body, line)
+			}
+		}
+	}
+	var buf strings.Builder
+	if len(brief) > 0 {
+		fmt.Fprintf(&buf, "<p class=\"brief\">%s</p>\n", html.EscapeString(strings.Join(brief, " ")))
+	}
+	if len(body) > 0 {
+		fmt.Fprintf(&buf, "<p>%s</p>\n", html.EscapeString(strings.Join(body, " ")))
+	}
+	if len(params) > 0 {
+		buf.WriteString("<dl class=\"params\">\n")
+		for _, p := range params {
+			fmt.Fprintf(&buf, "<dt>%s</dt><dd>%s</dd>\n", html.EscapeString(p.Name), html.EscapeString(p.Desc))
+		}
+		buf.WriteString("</dl>\n")
+	}
+	if len(returns) > 0 {
+		fmt.Fprintf(&buf, "<p class=\"returns\"><strong>Returns:</strong> %s</p>\n", html.EscapeString(strings.Join(returns, " ")))
+	}
+	if len(see) > 0 {
+		buf.WriteString("<p class=\"see\"><strong>See also:</strong> ")
+		buf.WriteString(strings.Join(linkifySeeRefs(see, targets), ", "))
+		buf.WriteString("</p>\n")
+	}
+	return buf.String()
+}
+
+// seeRefSplitRE splits an @see tag's argument into the individual
+// cross-reference spellings it lists, tolerating comma- or
+// whitespace-separated lists ("@see Foo, Bar" and "@see Foo Bar" alike).
+var seeRefSplitRE = regexp.MustCompile(`[,\s]+`)
+
+// linkifySeeRefs renders each cross-reference spelling listed across the
+// @see lines in see as a link to its anchor id in targets, falling back to
+// plain escaped text for a spelling with no known target. Resolving
+// references by exact lookup, rather than scanning the rendered HTML for
+// occurrences of a symbol's name, keeps an @see reference from ever being
+// confused with markup, and keeps linkification scoped to @see rather than
+// every word of prose.
+func linkifySeeRefs(see []string, targets map[string]string) []string {
+	var refs []string
+	for _, s := range see {
+		for _, ref := range seeRefSplitRE.Split(strings.TrimSpace(s), -1) {
+			if ref != "" {
+				refs = append(refs, ref)
+			}
+		}
+	}
+	linked := make([]string, len(refs))
+	for i, ref := range refs {
+		if anchor, ok := targets[ref]; ok {
+			linked[i] = fmt.Sprintf(`<a href="#%s">%s</a>`, html.EscapeString(anchor), html.EscapeString(ref))
+			continue
+		}
+		linked[i] = html.EscapeString(ref)
+	}
+	return linked
+}
+
+// doxygenTags are the Doxygen tags FormatDoxygen recognizes.
+var doxygenTags = map[string]bool{
+	"brief": true, "param": true, "return": true, "returns": true, "see": true,
+}
+
+// splitTag splits a trimmed comment line into a recognized Doxygen tag
+// ("brief", "param", "return", "returns", "see") and the rest of the line,
+// or returns an empty tag if line does not start with one.
+func splitTag(line string) (tag, rest string) {
+	for _, prefix := range [...]string{"@", "\\"} {
+		if !strings.HasPrefix(line, prefix) {
+			continue
+		}
+		fields := strings.SplitN(line[len(prefix):], " ", 2)
+		if !doxygenTags[fields[0]] {
+			continue
+		}
+		tag = fields[0]
+		if len(fields) > 1 {
+			rest = strings.TrimSpace(fields[1])
+		}
+		return tag, rest
+	}
+	return "", line
+}
+
+// splitWord splits s into its first whitespace-delimited word and the
+// remainder of the string.
+func splitWord(s string) (word, rest string) {
+	fields := strings.SplitN(s, " ", 2)
+	word = fields[0]
+	if len(fields) > 1 {
+		rest = strings.TrimSpace(fields[1])
+	}
+	return word, rest
+}
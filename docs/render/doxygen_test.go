@@ -0,0 +1,62 @@
+package render
+
+import "testing"
+
+func TestLinkifySeeRefs(t *testing.T) {
+	targets := map[string]string{"Foo": "struct.Foo", "S::params": "S::params", "params": "S::params"}
+
+	golden := []struct {
+		name string
+		see  []string
+		want []string
+	}{
+		{
+			name: "a known bare spelling resolves to its qualified anchor",
+			see:  []string{"Foo"},
+			want: []string{`<a href="#struct.Foo">Foo</a>`},
+		},
+		{
+			name: "an unknown spelling falls back to plain escaped text",
+			see:  []string{"Bar"},
+			want: []string{"Bar"},
+		},
+		{
+			name: "a comma-separated list is split into individual refs",
+			see:  []string{"Foo, Bar"},
+			want: []string{`<a href="#struct.Foo">Foo</a>`, "Bar"},
+		},
+		{
+			name: "a bare spelling matching a class value FormatDoxygen emits is not treated specially",
+			see:  []string{"params"},
+			want: []string{`<a href="#S::params">params</a>`},
+		},
+	}
+	for _, g := range golden {
+		t.Run(g.name, func(t *testing.T) {
+			got := linkifySeeRefs(g.see, targets)
+			if len(got) != len(g.want) {
+				t.Fatalf("linkifySeeRefs(%v) = %v, want %v", g.see, got, g.want)
+			}
+			for i := range got {
+				if got[i] != g.want[i] {
+					t.Errorf("linkifySeeRefs(%v)[%d] = %q, want %q", g.see, i, got[i], g.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFormatDoxygenSeeDoesNotCorruptMarkup(t *testing.T) {
+	// A symbol whose bare name collides with a class value FormatDoxygen
+	// emits elsewhere ("params") must not turn that class attribute into
+	// broken nested-anchor markup; @see refs are resolved by exact lookup,
+	// never by scanning the rendered HTML.
+	targets := map[string]string{"params": "S::params"}
+	raw := "/**\n * @param x the thing.\n * @see params\n */"
+	got := FormatDoxygen(raw, targets)
+	want := "<dl class=\"params\">\n<dt>x</dt><dd>the thing.</dd>\n</dl>\n" +
+		"<p class=\"see\"><strong>See also:</strong> <a href=\"#S::params\">params</a></p>\n"
+	if got != want {
+		t.Errorf("FormatDoxygen() = %q, want %q", got, want)
+	}
+}
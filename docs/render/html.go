@@ -0,0 +1,60 @@
+package render
+
+import (
+	"html/template"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultHTMLTemplate is the built-in template RenderHTML falls back to
+// when tmpl is nil, grouping symbols by file and kind, followed by a Bugs
+// page listing the BUG/TODO/FIXME notes.
+const DefaultHTMLTemplate = `<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>API Reference</title></head>
+<body>
+{{range .Files}}
+<h1>{{.Path}}</h1>
+{{$file := .}}
+{{range $.KindOrder}}
+{{$kind := .}}
+{{with index $file.Symbols $kind}}
+<h2>{{$kind}}</h2>
+<dl>
+{{range .}}
+<dt id="{{.Name}}">{{.Name}}</dt>
+<dd>{{.DocHTML}}</dd>
+{{end}}
+</dl>
+{{end}}
+{{end}}
+{{end}}
+{{if .Notes}}
+<h1>Bugs</h1>
+{{range $kind, $entries := .Notes}}
+<h2>{{$kind}}</h2>
+<ul>
+{{range $entries}}
+<li>{{.File}}:{{.Line}}: ({{.Author}}) {{.Body}}</li>
+{{end}}
+</ul>
+{{end}}
+{{end}}
+</body>
+</html>
+`
+
+// RenderHTML renders site as HTML to w using tmpl. If tmpl is nil,
+// DefaultHTMLTemplate is used, letting callers plug in their own
+// html/template without otherwise changing the pipeline.
+func RenderHTML(w io.Writer, site *Site, tmpl *template.Template) error {
+	if tmpl == nil {
+		var err error
+		tmpl, err = template.New("site").Parse(DefaultHTMLTemplate)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return errors.WithStack(tmpl.Execute(w, site))
+}
@@ -0,0 +1,65 @@
+package render
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// htmlTagRE strips HTML tags out of a Symbol's DocHTML when rendering to
+// Markdown, since Markdown renderers re-escape raw HTML inconsistently.
+var htmlTagRE = regexp.MustCompile(`<[^>]+>`)
+
+// RenderMarkdown renders site as Markdown to w, grouping symbols by file
+// and kind, followed by a Bugs section listing the BUG/TODO/FIXME notes.
+func RenderMarkdown(w io.Writer, site *Site) error {
+	for _, file := range site.Files {
+		if _, err := fmt.Fprintf(w, "# %s\n\n", file.Path); err != nil {
+			return errors.WithStack(err)
+		}
+		for _, kind := range KindOrder {
+			syms := file.Symbols[kind]
+			if len(syms) == 0 {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "## %s\n\n", kind); err != nil {
+				return errors.WithStack(err)
+			}
+			for _, sym := range syms {
+				stripped := htmlTagRE.ReplaceAllString(string(sym.DocHTML), "")
+				text := strings.TrimSpace(html.UnescapeString(stripped))
+				if _, err := fmt.Fprintf(w, "### %s\n\n%s\n\n", sym.Name, text); err != nil {
+					return errors.WithStack(err)
+				}
+			}
+		}
+	}
+	if len(site.Notes) == 0 {
+		return nil
+	}
+	if _, err := fmt.Fprint(w, "# Bugs\n\n"); err != nil {
+		return errors.WithStack(err)
+	}
+	for _, kind := range []string{"BUG", "TODO", "FIXME"} {
+		entries := site.Notes[kind]
+		if len(entries) == 0 {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "## %s\n\n", kind); err != nil {
+			return errors.WithStack(err)
+		}
+		for _, entry := range entries {
+			if _, err := fmt.Fprintf(w, "- %s:%d: (%s) %s\n", entry.File, entry.Line, entry.Author, entry.Body); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		if _, err := fmt.Fprintln(w); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package render
+
+import (
+	"bytes"
+	"html/template"
+	"strings"
+	"testing"
+)
+
+func TestRenderMarkdownUnescapesEntities(t *testing.T) {
+	site := &Site{
+		Files: []*File{
+			{
+				Path: "foo.h",
+				Symbols: map[Kind][]Symbol{
+					KindFunc: {
+						{
+							Name:    "Foo",
+							Kind:    KindFunc,
+							DocHTML: template.HTML(`<p>Pass a &lt; b and an int &amp;x.</p>` + "\n"),
+						},
+					},
+				},
+			},
+		},
+	}
+	var buf bytes.Buffer
+	if err := RenderMarkdown(&buf, site); err != nil {
+		t.Fatalf("RenderMarkdown() error = %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "Pass a < b and an int &x.") {
+		t.Errorf("RenderMarkdown() = %q, want unescaped entities", out)
+	}
+	if strings.Contains(out, "&lt;") || strings.Contains(out, "&amp;") {
+		t.Errorf("RenderMarkdown() = %q, want no raw HTML entities", out)
+	}
+}
@@ -0,0 +1,166 @@
+// Package render consumes the JSON produced by cdoc2json, together with
+// the parsed AST for structural info, and emits browsable HTML and
+// Markdown, analogous to how golang.org/x/website renders go/doc output.
+package render
+
+import (
+	"html/template"
+	"sort"
+	"strings"
+
+	"github.com/go-clang/clang-v3.9/clang"
+	"github.com/mewspring/cc"
+	"github.com/mewspring/cdoc2json/docs"
+)
+
+// Kind categorizes a symbol for grouping in rendered output.
+type Kind string
+
+// Kinds a Symbol can be grouped under.
+const (
+	KindFunc  Kind = "Functions"
+	KindType  Kind = "Types"
+	KindVar   Kind = "Variables"
+	KindMacro Kind = "Macros"
+)
+
+// KindOrder is the display order of symbol kinds on a rendered page.
+var KindOrder = []Kind{KindFunc, KindType, KindVar, KindMacro}
+
+// Symbol is a single documented identifier, ready for rendering.
+type Symbol struct {
+	// Name is the symbol's qualified name, as recorded in doc_comments.json.
+	Name string
+	// Kind groups the symbol on the rendered page.
+	Kind Kind
+	// DocHTML is the symbol's doc comment, rendered as semantic HTML with
+	// Doxygen tags formatted and cross-references linkified.
+	DocHTML template.HTML
+}
+
+// File groups the documented symbols declared in one source file.
+type File struct {
+	// Path is the source file path, as recorded by Clang.
+	Path string
+	// Symbols maps each kind to the symbols of that kind declared in Path,
+	// sorted by name.
+	Symbols map[Kind][]Symbol
+}
+
+// Site is the full set of content to render for one cdoc2json run: the
+// documented symbols grouped by file and kind, plus the BUG/TODO/FIXME
+// notes extracted alongside them.
+type Site struct {
+	Files []*File
+	Notes map[string][]docs.NoteEntry
+}
+
+// KindOrder returns the display order of symbol kinds, for use from
+// templates.
+func (s *Site) KindOrder() []Kind {
+	return KindOrder
+}
+
+// Build groups decls with an entry in docComments into a Site ready for
+// rendering, one File per distinct declaration source, cross-referencing
+// @see targets against the documented symbol names.
+func Build(decls []docs.QualifiedDecl, docComments map[string]string) *Site {
+	var names []string
+	for _, decl := range decls {
+		if _, ok := docComments[decl.Name]; ok {
+			names = append(names, decl.Name)
+		}
+	}
+	targets := linkifyTargets(names)
+
+	files := make(map[string]*File)
+	var order []string
+	for _, decl := range decls {
+		body, ok := docComments[decl.Name]
+		if !ok {
+			continue
+		}
+		path := decl.Node.Loc.File
+		file, ok := files[path]
+		if !ok {
+			file = &File{Path: path, Symbols: make(map[Kind][]Symbol)}
+			files[path] = file
+			order = append(order, path)
+		}
+		kind := kindOf(decl.Node)
+		file.Symbols[kind] = append(file.Symbols[kind], Symbol{
+			Name:    decl.Name,
+			Kind:    kind,
+			DocHTML: template.HTML(FormatDoxygen(body, targets)),
+		})
+	}
+	sort.Strings(order)
+	site := &Site{}
+	for _, path := range order {
+		file := files[path]
+		for kind, syms := range file.Symbols {
+			sort.Slice(syms, func(i, j int) bool { return syms[i].Name < syms[j].Name })
+			file.Symbols[kind] = syms
+		}
+		site.Files = append(site.Files, file)
+	}
+	return site
+}
+
+// kindOf classifies decl for grouping on the rendered page.
+func kindOf(n *cc.Node) Kind {
+	switch n.Body.Kind() {
+	case clang.Cursor_FunctionDecl:
+		return KindFunc
+	case clang.Cursor_MacroDefinition:
+		return KindMacro
+	case clang.Cursor_VarDecl, clang.Cursor_FieldDecl, clang.Cursor_EnumConstantDecl:
+		return KindVar
+	default:
+		// Cursor_StructDecl, Cursor_UnionDecl, Cursor_EnumDecl, Cursor_TypedefDecl.
+		return KindType
+	}
+}
+
+// linkifyTargets maps every spelling an @see tag might use to refer to a
+// symbol to that symbol's qualified anchor id: the qualified name itself
+// (e.g. "struct.Foo"), and, when unambiguous, the bare spelling an author
+// would actually write (e.g. "Foo"). An ambiguous bare spelling (shared by
+// more than one qualified symbol) is left out, since there is no single
+// correct target to link it to.
+func linkifyTargets(names []string) map[string]string {
+	bare := make(map[string]string)
+	ambiguous := make(map[string]bool)
+	for _, name := range names {
+		b := bareName(name)
+		if other, ok := bare[b]; ok && other != name {
+			ambiguous[b] = true
+			continue
+		}
+		bare[b] = name
+	}
+	targets := make(map[string]string, len(names)+len(bare))
+	for _, name := range names {
+		targets[name] = name
+	}
+	for b, name := range bare {
+		if ambiguous[b] {
+			continue
+		}
+		targets[b] = name
+	}
+	return targets
+}
+
+// bareName returns the spelling a human would type to refer to decl's
+// qualified name in an @see tag, e.g. "struct.Foo" -> "Foo", "enum.E.A" ->
+// "A", "S::field" -> "field".
+func bareName(qualified string) string {
+	if i := strings.LastIndex(qualified, "::"); i >= 0 {
+		return qualified[i+2:]
+	}
+	if i := strings.LastIndex(qualified, "."); i >= 0 {
+		return qualified[i+1:]
+	}
+	return qualified
+}
@@ -0,0 +1,56 @@
+package render
+
+import "testing"
+
+func TestBareName(t *testing.T) {
+	golden := []struct {
+		qualified string
+		want      string
+	}{
+		{"struct.S", "S"},
+		{"union.U", "U"},
+		{"enum.E", "E"},
+		{"enum.E.A", "A"},
+		{"typedef.Foo", "Foo"},
+		{"macro.FOO", "FOO"},
+		{"S::field", "field"},
+		{"Foo", "Foo"},
+		{"ns.Foo", "Foo"},
+	}
+	for _, g := range golden {
+		if got := bareName(g.qualified); got != g.want {
+			t.Errorf("bareName(%q) = %q, want %q", g.qualified, got, g.want)
+		}
+	}
+}
+
+func TestLinkifyTargets(t *testing.T) {
+	t.Run("bare spelling resolves to the qualified anchor", func(t *testing.T) {
+		targets := linkifyTargets([]string{"struct.Foo"})
+		if got, ok := targets["Foo"]; !ok || got != "struct.Foo" {
+			t.Errorf("targets[Foo] = (%q, %v), want (struct.Foo, true)", got, ok)
+		}
+		if got, ok := targets["struct.Foo"]; !ok || got != "struct.Foo" {
+			t.Errorf("targets[struct.Foo] = (%q, %v), want (struct.Foo, true)", got, ok)
+		}
+	})
+	t.Run("ambiguous bare spelling is left unresolved", func(t *testing.T) {
+		targets := linkifyTargets([]string{"struct.Foo", "typedef.Foo"})
+		if _, ok := targets["Foo"]; ok {
+			t.Errorf("targets[Foo] present, want ambiguous bare spelling to be dropped")
+		}
+		if _, ok := targets["struct.Foo"]; !ok {
+			t.Errorf("targets[struct.Foo] missing, want the qualified name to still resolve")
+		}
+	})
+	t.Run("a bare spelling matching a class value FormatDoxygen emits still resolves", func(t *testing.T) {
+		// linkifyTargets has no notion of FormatDoxygen's markup, since
+		// @see refs are resolved by exact lookup rather than scanned out of
+		// rendered HTML; a field or var named e.g. "params" is just another
+		// spelling to resolve, not a collision to guard against.
+		targets := linkifyTargets([]string{"S::params"})
+		if got, ok := targets["params"]; !ok || got != "S::params" {
+			t.Errorf("targets[params] = (%q, %v), want (S::params, true)", got, ok)
+		}
+	})
+}
@@ -7,15 +7,12 @@ package main
 import (
 	"flag"
 	"fmt"
-	"go/scanner"
-	"go/token"
 	"io/ioutil"
 	"log"
 	"os"
 	"sort"
 	"strings"
 
-	"github.com/go-clang/clang-v3.9/clang"
 	"github.com/mewkiz/pkg/jsonutil"
 	"github.com/mewkiz/pkg/term"
 	"github.com/mewspring/cc"
@@ -47,34 +44,65 @@ func main() {
 	clangArgs = strings.Split(clangArgsRaw, "|")
 	// map from identifier to comment.
 	commentFromIdent := make(map[string]string)
+	// map from marker kind to notes of that kind.
+	notesByKind := make(map[string][]docs.NoteEntry)
+	// map from header path to its file-level doc comment.
+	fileDocs := make(map[string]docs.FileDoc)
 	for _, srcPath := range flag.Args() {
-		if err := parse(srcPath, commentFromIdent, clangArgs...); err != nil {
+		if err := parse(srcPath, commentFromIdent, notesByKind, fileDocs, clangArgs...); err != nil {
 			log.Fatalf("%+v", err)
 		}
 	}
 	dbg.Printf("creating %q", output)
-	if err := jsonutil.WriteFile(output, commentFromIdent); err != nil {
+	out := docs.Output{
+		Docs:    commentFromIdent,
+		Notes:   notesByKind,
+		FileDoc: fileDocs,
+	}
+	if err := jsonutil.WriteFile(output, out); err != nil {
 		log.Fatalf("%+v", err)
 	}
 }
 
-func parse(srcPath string, commentFromIdent map[string]string, clangArgs ...string) error {
+func parse(srcPath string, commentFromIdent map[string]string, notesByKind map[string][]docs.NoteEntry, fileDocs map[string]docs.FileDoc, clangArgs ...string) error {
 	dbg.Printf("parsing %q", srcPath)
-	comments, err := parseComments(srcPath)
+	src, err := ioutil.ReadFile(srcPath)
 	if err != nil {
 		return errors.WithStack(err)
 	}
+	lines := strings.Split(string(src), "\n")
+	comments, warnings := docs.ScanComments(srcPath, src)
+	for _, w := range warnings {
+		warn.Print(w)
+	}
 	// Merge consequtive line comments.
-	comments = mergeLineComments(comments)
+	comments = docs.MergeLineComments(comments)
 	file, err := cc.ParseFile(srcPath, clangArgs...)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 	defer file.Close()
 	decls := findDecls(file.Root)
-	docComments := addDocComments(decls, comments)
+	var firstDeclLoc *cc.Location
+	if len(decls) > 0 {
+		loc := decls[0].Node.Loc
+		firstDeclLoc = &loc
+	}
+	// Extract the file-level doc comment before doc comment association, so
+	// it is recorded separately rather than attached to the first decl.
+	fileDoc, comments := docs.ExtractFileDoc(comments, lines, firstDeclLoc)
+	if fileDoc != nil {
+		fileDocs[srcPath] = *fileDoc
+	}
+	// Extract BUG/TODO/FIXME markers before doc comment association, so they
+	// are recorded as notes rather than attached to a declaration.
+	notes, comments := docs.ExtractNotes(comments)
+	for kind, entries := range notes {
+		notesByKind[kind] = append(notesByKind[kind], entries...)
+	}
+	docComments := addDocComments(decls, comments, lines)
 	for _, docComment := range docComments {
-		ident := docComment.Decl.Body.Spelling()
+		ident := docComment.Name
 		new := docComment.Comment.Lit
 		if old, ok := commentFromIdent[ident]; ok {
 			warn.Printf("doc comment for %q already present; old %q, new %q", ident, old, new)
@@ -87,136 +115,48 @@ func parse(srcPath string, commentFromIdent map[string]string, clangArgs ...stri
 
 func printDocComments(docComments []docs.DocComment) {
 	for _, docComment := range docComments {
-		fmt.Println(docComment.Decl.Body.Spelling())
+		fmt.Println(docComment.Name)
 		fmt.Println(docComment.Comment.Lit)
 	}
 }
 
-func mergeLineComments(comments []docs.Comment) []docs.Comment {
-	var new []docs.Comment
-	for i := 0; i < len(comments); i++ {
-		a := comments[i]
-		for j := i + 1; j < len(comments); j++ {
-			b := comments[j]
-			if isConsequtiveLineComments(a, b) {
-				a = mergeLineComment(a, b)
-				i++
-			}
-		}
-		new = append(new, a)
-	}
-	return new
-}
-
-func isConsequtiveLineComments(a, b docs.Comment) bool {
-	if !strings.HasPrefix(a.Lit, "//") {
-		return false
-	}
-	if !strings.HasPrefix(b.Lit, "//") {
-		return false
+// addDocComments associates decls with the comments that document them,
+// using a docs.CommentMap to apply proper lead/line/free association rules
+// (tolerant of intervening preprocessor lines) instead of a plain "one line
+// before" heuristic.
+func addDocComments(decls []docs.QualifiedDecl, comments []docs.Comment, lines []string) []docs.DocComment {
+	nodes := make([]*cc.Node, len(decls))
+	nameOf := make(map[*cc.Node]string, len(decls))
+	for i, decl := range decls {
+		nodes[i] = decl.Node
+		nameOf[decl.Node] = decl.Name
 	}
-	return a.Loc.Line+uint32(strings.Count(a.Lit, "\n")) == b.Loc.Line-1
-}
-
-func mergeLineComment(a, b docs.Comment) docs.Comment {
-	a.Lit += "\n" + b.Lit
-	return a
-}
-
-func addDocComments(decls []*cc.Node, comments []docs.Comment) []docs.DocComment {
+	cmap := docs.NewCommentMap(nodes, comments, lines)
 	var docComments []docs.DocComment
-	i := 0 // current comment index.
-loop:
-	for _, decl := range decls {
-		for i < len(comments) {
-			comment := comments[i]
-			commEndLoc := comment.Loc
-			commEndLoc.Line += uint32(strings.Count(comment.Lit, "\n"))
-			if less(decl.Loc, commEndLoc) {
-				// skip decl, decl before comment.
-				continue loop
+	for _, node := range nodes {
+		for _, comment := range cmap[node] {
+			// Only a lead comment documents a declaration; line and free
+			// comments are not doc comments and would otherwise clobber the
+			// real lead comment recorded for this decl.
+			if comment.Assoc != docs.AssocLead {
+				continue
 			}
-			if decl.Loc.Line-commEndLoc.Line <= 1 {
-				// doc comment.
-				docComment := docs.DocComment{
-					Decl:    decl,
-					Comment: comment,
-				}
-				docComments = append(docComments, docComment)
-			}
-			i++
+			docComments = append(docComments, docs.DocComment{
+				Decl:    node,
+				Name:    nameOf[node],
+				Comment: comment,
+			})
 		}
 	}
 	return docComments
 }
 
-func less(a, b cc.Location) bool {
-	switch {
-	case a.Line < b.Line:
-		return true
-	case a.Line > b.Line:
-		return false
-	}
-	// case a.Line == b.Line:
-	return a.Col < b.Col
-}
-
-func findDecls(root *cc.Node) []*cc.Node {
-	var decls []*cc.Node
-	visit := func(n *cc.Node) {
-		switch n.Body.Kind() {
-		case clang.Cursor_VarDecl, clang.Cursor_FunctionDecl:
-			decls = append(decls, n)
-		}
-	}
-	cc.Walk(root, visit)
-	// sort decls.
+// findDecls returns every documentable declaration reachable from root,
+// sorted by source location.
+func findDecls(root *cc.Node) []docs.QualifiedDecl {
+	decls := docs.WalkDecls(root)
 	sort.Slice(decls, func(i, j int) bool {
-		return less(decls[i].Loc, decls[j].Loc)
+		return docs.Less(decls[i].Node.Loc, decls[j].Node.Loc)
 	})
 	return decls
 }
-
-func parseComments(srcPath string) ([]docs.Comment, error) {
-	src, err := ioutil.ReadFile(srcPath)
-	if err != nil {
-		return nil, errors.WithStack(err)
-	}
-	var comments []docs.Comment
-	fset := token.NewFileSet()
-	file := fset.AddFile(srcPath, 1, len(src))
-	s := &scanner.Scanner{}
-	eh := func(pos token.Position, msg string) {
-		if msg == "illegal character U+0023 '#'" {
-			// Ignore pre-process directives.
-			return
-		}
-		warn.Printf("pos: %v, msg: %v", pos, msg)
-	}
-	s.Init(file, src, eh, scanner.ScanComments)
-	for {
-		p, tok, lit := s.Scan()
-		if tok == token.EOF {
-			break
-		}
-		pos := fset.Position(p)
-		//dbg.Printf("pos: %v, tok: %v, lit: %v", pos, tok, lit)
-		if tok == token.COMMENT {
-			loc := cc.Location{
-				File: pos.Filename,
-				Line: uint32(pos.Line),
-				Col:  uint32(pos.Column),
-			}
-			comment := docs.Comment{
-				Lit: lit,
-				Loc: loc,
-			}
-			comments = append(comments, comment)
-		}
-	}
-	// TODO: remove, should not be needed as scanner results are already sorted.
-	sort.Slice(comments, func(i, j int) bool {
-		return less(comments[i].Loc, comments[j].Loc)
-	})
-	return comments, nil
-}